@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// adminWaitlistEntry mirrors a row from the waitlist table for JSON/CSV export.
+type adminWaitlistEntry struct {
+	ID        int64  `json:"id"`
+	Email     string `json:"email"`
+	Status    string `json:"status"`
+	ClientIP  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+	CreatedAt string `json:"created_at"`
+}
+
+// adminHoneypotEntry mirrors a row from the waitlist_honeypot table for the JSON admin listing.
+type adminHoneypotEntry struct {
+	ID        int64  `json:"id"`
+	Email     string `json:"email"`
+	TrapValue string `json:"trap_value"`
+	ClientIP  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+	CreatedAt string `json:"created_at"`
+}
+
+// runAdminCmd dispatches `waitlist admin <sub>` commands.
+func runAdminCmd(sub string, args []string) error {
+	switch sub {
+	case "add-user":
+		addUserCmd := flag.NewFlagSet("admin add-user", flag.ExitOnError)
+		dbTarget := addUserCmd.String("f", "", "path to SQLite database file, or a postgres:// URL (defaults to waitlist.db, $DATABASE_URL, or $DATABASE_PATH)")
+		driver := addUserCmd.String("driver", "", "storage driver: sqlite or postgres (defaults to inferring from -f/$DATABASE_URL)")
+		addUserCmd.Parse(args)
+
+		if addUserCmd.NArg() != 1 {
+			return errors.New("usage: admin add-user [-f path|url] [-driver sqlite|postgres] <email>")
+		}
+
+		token, err := addAdminUser(*dbTarget, *driver, addUserCmd.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("admin user created; token (store this, it will not be shown again): %s\n", token)
+		return nil
+	default:
+		return fmt.Errorf("unknown admin subcommand %q", sub)
+	}
+}
+
+// addAdminUser inserts a new admin user and returns the freshly generated token.
+func addAdminUser(dbTargetOverride, driver, email string) (string, error) {
+	target := resolveDatabaseTarget(dbTargetOverride)
+
+	store, err := openStore(target, driver)
+	if err != nil {
+		return "", fmt.Errorf("database setup failed: %w", err)
+	}
+	defer store.Close()
+
+	token, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+
+	if err := store.AddAdminUser(context.Background(), email, hashToken(token)); err != nil {
+		if errors.Is(err, ErrUserAlreadyExists) {
+			return "", fmt.Errorf("user %q already exists", email)
+		}
+		return "", fmt.Errorf("insert user: %w", err)
+	}
+
+	return token, nil
+}
+
+// generateToken returns a random 32-byte token hex-encoded for display to the operator.
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken returns the sha256 hex digest of token, the form stored in the users table.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticate reports whether token matches a known admin user, comparing in constant time.
+func (s *server) authenticate(ctx context.Context, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	want := hashToken(token)
+
+	hashes, err := s.store.AdminTokenHashes(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, got := range hashes {
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// adminAuth wraps next with bearer-token authentication against the users table.
+func (s *server) adminAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		const prefix = "Bearer "
+		if !strings.HasPrefix(authHeader, prefix) {
+			writeMessage(w, http.StatusUnauthorized, "missing bearer token", false)
+			return
+		}
+		token := strings.TrimSpace(strings.TrimPrefix(authHeader, prefix))
+
+		ok, err := s.authenticate(r.Context(), token)
+		if err != nil {
+			log.Printf("admin authentication failed: %v", err)
+			writeMessage(w, http.StatusInternalServerError, "internal server error", false)
+			return
+		}
+		if !ok {
+			writeMessage(w, http.StatusUnauthorized, "invalid bearer token", false)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// registerAdminRoutes mounts the authenticated admin management API on mux.
+func (s *server) registerAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/admin/waitlist", s.adminAuth(s.adminListWaitlistHandler))
+	mux.HandleFunc("/api/v1/admin/waitlist/", s.adminAuth(s.adminDeleteWaitlistHandler))
+	mux.HandleFunc("/api/v1/admin/honeypot", s.adminAuth(s.adminListHoneypotHandler))
+	mux.HandleFunc("/api/v1/admin/waitlist/export", s.adminAuth(s.adminExportWaitlistHandler))
+}
+
+func (s *server) adminListWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := s.listWaitlistRows(r.Context())
+	if err != nil {
+		log.Printf("admin list waitlist failed: %v", err)
+		writeMessage(w, http.StatusInternalServerError, "internal server error", false)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *server) adminListHoneypotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := s.listHoneypotRows(r.Context())
+	if err != nil {
+		log.Printf("admin list honeypot failed: %v", err)
+		writeMessage(w, http.StatusInternalServerError, "internal server error", false)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *server) adminDeleteWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/admin/waitlist/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeMessage(w, http.StatusBadRequest, "invalid waitlist id", false)
+		return
+	}
+
+	removed, err := s.store.DeleteWaitlistByID(r.Context(), id)
+	if err != nil {
+		log.Printf("admin delete waitlist failed: %v", err)
+		writeMessage(w, http.StatusInternalServerError, "internal server error", false)
+		return
+	}
+	if !removed {
+		writeMessage(w, http.StatusNotFound, "waitlist entry not found", false)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) adminExportWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := s.listWaitlistRows(r.Context())
+	if err != nil {
+		log.Printf("admin export waitlist failed: %v", err)
+		writeMessage(w, http.StatusInternalServerError, "internal server error", false)
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = "json"
+	}
+
+	switch format {
+	case "json":
+		writeJSON(w, http.StatusOK, entries)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="waitlist.csv"`)
+		w.WriteHeader(http.StatusOK)
+
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"id", "email", "status", "client_ip", "user_agent", "created_at"})
+		for _, e := range entries {
+			_ = cw.Write([]string{strconv.FormatInt(e.ID, 10), csvSafe(e.Email), e.Status, csvSafe(e.ClientIP), csvSafe(e.UserAgent), e.CreatedAt})
+		}
+		cw.Flush()
+	default:
+		writeMessage(w, http.StatusBadRequest, "unsupported export format", false)
+	}
+}
+
+func (s *server) listWaitlistRows(ctx context.Context) ([]adminWaitlistEntry, error) {
+	records, err := s.store.ListWaitlist(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]adminWaitlistEntry, len(records))
+	for i, r := range records {
+		entries[i] = adminWaitlistEntry{ID: r.ID, Email: r.Email, Status: r.Status, ClientIP: r.ClientIP, UserAgent: r.UserAgent, CreatedAt: r.CreatedAt}
+	}
+	return entries, nil
+}
+
+func (s *server) listHoneypotRows(ctx context.Context) ([]adminHoneypotEntry, error) {
+	records, err := s.store.ListHoneypot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]adminHoneypotEntry, len(records))
+	for i, r := range records {
+		entries[i] = adminHoneypotEntry{ID: r.ID, Email: r.Email, TrapValue: r.TrapValue, ClientIP: r.ClientIP, UserAgent: r.UserAgent, CreatedAt: r.CreatedAt}
+	}
+	return entries, nil
+}
+
+// csvSafe prefixes values that spreadsheet applications would interpret as a
+// formula (leading =, +, -, or @) with a single quote, so exported CSVs can't
+// be used to smuggle formulas into an admin's spreadsheet via a submitted
+// email or user agent.
+func csvSafe(field string) string {
+	if len(field) > 0 && strings.ContainsRune("=+-@", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newTestAdminServer(t *testing.T) (*server, string) {
+	t.Helper()
+
+	store, err := InMemory()
+	if err != nil {
+		t.Fatalf("InMemory: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	token, err := generateToken()
+	if err != nil {
+		t.Fatalf("generateToken: %v", err)
+	}
+	if err := store.AddAdminUser(context.Background(), "admin@example.com", hashToken(token)); err != nil {
+		t.Fatalf("AddAdminUser: %v", err)
+	}
+
+	return &server{store: store, mailer: NullMailer{}}, token
+}
+
+func TestHashTokenIsDeterministicAndDistinct(t *testing.T) {
+	if hashToken("token-a") != hashToken("token-a") {
+		t.Fatalf("hashToken is not deterministic for the same input")
+	}
+	if hashToken("token-a") == hashToken("token-b") {
+		t.Fatalf("hashToken produced the same digest for two different tokens")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv, token := newTestAdminServer(t)
+	ctx := context.Background()
+
+	ok, err := srv.authenticate(ctx, token)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if !ok {
+		t.Fatalf("authenticate rejected the correct token")
+	}
+
+	if ok, err := srv.authenticate(ctx, "wrong-token"); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	} else if ok {
+		t.Fatalf("authenticate accepted an incorrect token")
+	}
+
+	if ok, err := srv.authenticate(ctx, ""); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	} else if ok {
+		t.Fatalf("authenticate accepted an empty token")
+	}
+}
+
+func newTestAdminHTTPServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	srv, token := newTestAdminServer(t)
+	mux := http.NewServeMux()
+	srv.registerAdminRoutes(mux)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts, token
+}
+
+func TestAdminWaitlistRequiresBearerToken(t *testing.T) {
+	ts, _ := newTestAdminHTTPServer(t)
+
+	resp, err := http.Get(ts.URL + "/api/v1/admin/waitlist")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401 for a request with no bearer token", resp.StatusCode)
+	}
+}
+
+func TestAdminWaitlistRejectsWrongToken(t *testing.T) {
+	ts, _ := newTestAdminHTTPServer(t)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/waitlist", nil)
+	req.Header.Set("Authorization", "Bearer wrong-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401 for a request with an invalid bearer token", resp.StatusCode)
+	}
+}
+
+func TestAdminWaitlistAcceptsValidToken(t *testing.T) {
+	ts, token := newTestAdminHTTPServer(t)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/waitlist", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 for a request with a valid bearer token", resp.StatusCode)
+	}
+
+	var entries []adminWaitlistEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("entries = %+v, want empty waitlist", entries)
+	}
+}
+
+func TestAdminExportCSVGuardsFormulaInjection(t *testing.T) {
+	srv, token := newTestAdminServer(t)
+	if _, _, err := srv.store.UpsertPendingSignup(context.Background(), "=1+1@example.com", "confirm-token", "2999-01-01T00:00:00Z", "203.0.113.1", "ua"); err != nil {
+		t.Fatalf("UpsertPendingSignup: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	srv.registerAdminRoutes(mux)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/waitlist/export?format=csv", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV response: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("CSV rows = %v, want a header row plus one entry", rows)
+	}
+	if got := rows[1][1]; got != "'=1+1@example.com" {
+		t.Fatalf("exported email = %q, want a leading ' guarding against formula injection", got)
+	}
+}
+
+func TestAdminDeleteThenNotFound(t *testing.T) {
+	store, err := InMemory()
+	if err != nil {
+		t.Fatalf("InMemory: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	token, err := generateToken()
+	if err != nil {
+		t.Fatalf("generateToken: %v", err)
+	}
+	if err := store.AddAdminUser(context.Background(), "admin@example.com", hashToken(token)); err != nil {
+		t.Fatalf("AddAdminUser: %v", err)
+	}
+	if _, _, err := store.UpsertPendingSignup(context.Background(), "a@example.com", "confirm-token", "2999-01-01T00:00:00Z", "203.0.113.1", "ua"); err != nil {
+		t.Fatalf("UpsertPendingSignup: %v", err)
+	}
+
+	srv := &server{store: store, mailer: NullMailer{}}
+	mux := http.NewServeMux()
+	srv.registerAdminRoutes(mux)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	entries, err := srv.listWaitlistRows(context.Background())
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("listWaitlistRows: %v, %+v", err, entries)
+	}
+	id := entries[0].ID
+
+	idStr := strconv.FormatInt(id, 10)
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/v1/admin/waitlist/"+idStr, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("first delete status = %d, want 204", resp.StatusCode)
+	}
+
+	req2, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/v1/admin/waitlist/"+idStr, nil)
+	req2.Header.Set("Authorization", "Bearer "+token)
+	resp2, err := http.DefaultClient.Do(req2)
+	if err != nil {
+		t.Fatalf("DELETE: %v", err)
+	}
+	defer resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Fatalf("second delete status = %d, want 404", resp2.StatusCode)
+	}
+}
+
+func TestCSVSafePrefixesFormulaChars(t *testing.T) {
+	cases := map[string]string{
+		"=1+1@example.com":          "'=1+1@example.com",
+		"+14155551234@example.com":  "'+14155551234@example.com",
+		"-HYPERLINK(1)@example.com": "'-HYPERLINK(1)@example.com",
+		"@example.com":              "'@example.com",
+		"plain@example.com":         "plain@example.com",
+		"":                          "",
+	}
+	for in, want := range cases {
+		if got := csvSafe(in); got != want {
+			t.Fatalf("csvSafe(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Mailer dispatches the double opt-in confirmation email for a new signup.
+type Mailer interface {
+	SendConfirmation(ctx context.Context, to, confirmURL, unsubscribeURL string) error
+}
+
+// NullMailer logs the confirmation link instead of sending an email. It is the
+// default mailer for the demo server and for deployments without SMTP configured.
+type NullMailer struct{}
+
+func (NullMailer) SendConfirmation(ctx context.Context, to, confirmURL, unsubscribeURL string) error {
+	log.Printf("confirmation email suppressed (no SMTP configured): to=%s link=%s unsubscribe=%s", to, confirmURL, unsubscribeURL)
+	return nil
+}
+
+// SMTPMailer sends the confirmation email over SMTP using net/smtp.
+type SMTPMailer struct {
+	Addr string
+	User string
+	Pass string
+	From string
+}
+
+func (m *SMTPMailer) SendConfirmation(ctx context.Context, to, confirmURL, unsubscribeURL string) error {
+	host := m.Addr
+	if idx := strings.LastIndex(host, ":"); idx != -1 {
+		host = host[:idx]
+	}
+
+	var auth smtp.Auth
+	if m.User != "" {
+		auth = smtp.PlainAuth("", m.User, m.Pass, host)
+	}
+
+	subject := "Confirm your waitlist signup"
+	body := fmt.Sprintf("Please confirm your email by visiting the link below:\r\n\r\n%s\r\n\r\nIf you didn't request this, or want to leave the waitlist, unsubscribe here:\r\n\r\n%s", confirmURL, unsubscribeURL)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s", m.From, to, subject, body)
+
+	return smtp.SendMail(m.Addr, auth, m.From, []string{to}, []byte(msg))
+}
+
+// mailerFromFlags builds a Mailer from explicit flag values, falling back to
+// the SMTP_ADDR / SMTP_USER / SMTP_PASS / SMTP_FROM environment variables,
+// and to NullMailer when no SMTP address is configured either way.
+func mailerFromFlags(addr, user, pass, from string) Mailer {
+	addr = firstNonEmpty(addr, os.Getenv("SMTP_ADDR"))
+	if addr == "" {
+		return NullMailer{}
+	}
+
+	return &SMTPMailer{
+		Addr: addr,
+		User: firstNonEmpty(user, os.Getenv("SMTP_USER")),
+		Pass: firstNonEmpty(pass, os.Getenv("SMTP_PASS")),
+		From: firstNonEmpty(from, os.Getenv("SMTP_FROM")),
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// baseURLFromFlags resolves the public base URL used to build confirmation and
+// unsubscribe links, preferring the explicit flag, then $BASE_URL, then a best
+// guess derived from the server's listen address.
+func baseURLFromFlags(flagVal string) string {
+	if v := firstNonEmpty(flagVal, os.Getenv("BASE_URL")); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	return "http://localhost" + serverAddr()
+}
+
+func buildConfirmURL(baseURL, path, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", strings.TrimRight(baseURL, "/"), path, url.QueryEscape(token))
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestMailerFromFlagsNoAddrReturnsNullMailer(t *testing.T) {
+	t.Setenv("SMTP_ADDR", "")
+	m := mailerFromFlags("", "", "", "")
+	if _, ok := m.(NullMailer); !ok {
+		t.Fatalf("mailerFromFlags with no SMTP address = %T, want NullMailer", m)
+	}
+}
+
+func TestMailerFromFlagsExplicitValuesWin(t *testing.T) {
+	t.Setenv("SMTP_ADDR", "env:587")
+	t.Setenv("SMTP_USER", "env-user")
+	t.Setenv("SMTP_PASS", "env-pass")
+	t.Setenv("SMTP_FROM", "env@example.com")
+
+	m := mailerFromFlags("flag:587", "flag-user", "flag-pass", "flag@example.com")
+	smtpMailer, ok := m.(*SMTPMailer)
+	if !ok {
+		t.Fatalf("mailerFromFlags with an explicit address = %T, want *SMTPMailer", m)
+	}
+	if smtpMailer.Addr != "flag:587" || smtpMailer.User != "flag-user" || smtpMailer.Pass != "flag-pass" || smtpMailer.From != "flag@example.com" {
+		t.Fatalf("mailerFromFlags = %+v, want flag values to take precedence over env", smtpMailer)
+	}
+}
+
+func TestMailerFromFlagsFallsBackToEnv(t *testing.T) {
+	t.Setenv("SMTP_ADDR", "env:587")
+	t.Setenv("SMTP_USER", "env-user")
+	t.Setenv("SMTP_PASS", "env-pass")
+	t.Setenv("SMTP_FROM", "env@example.com")
+
+	m := mailerFromFlags("", "", "", "")
+	smtpMailer, ok := m.(*SMTPMailer)
+	if !ok {
+		t.Fatalf("mailerFromFlags with env-only config = %T, want *SMTPMailer", m)
+	}
+	if smtpMailer.Addr != "env:587" || smtpMailer.User != "env-user" || smtpMailer.Pass != "env-pass" || smtpMailer.From != "env@example.com" {
+		t.Fatalf("mailerFromFlags = %+v, want env values", smtpMailer)
+	}
+}
+
+func TestBuildConfirmURL(t *testing.T) {
+	got := buildConfirmURL("http://example.com/", "/api/v1/waitlist/confirm", "a b+c")
+	want := "http://example.com/api/v1/waitlist/confirm?token=a+b%2Bc"
+	if got != want {
+		t.Fatalf("buildConfirmURL = %q, want %q", got, want)
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -10,14 +9,22 @@ import (
 	"html"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/mail"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
+)
+
+// confirmationTokenTTL is how long a pending signup's confirmation link stays valid.
+const confirmationTokenTTL = 48 * time.Hour
 
-	_ "modernc.org/sqlite"
+const (
+	waitlistStatusPending   = "pending"
+	waitlistStatusConfirmed = "confirmed"
 )
 
 // waitlistRequest models the expected JSON payload.
@@ -27,22 +34,24 @@ type waitlistRequest struct {
 }
 
 type server struct {
-	db *sql.DB
-}
+	store   Store
+	mailer  Mailer
+	baseURL string
 
-const schema = `
-CREATE TABLE IF NOT EXISTS waitlist (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    email TEXT NOT NULL UNIQUE,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-);
+	rateLimiter    *rateLimiter
+	trustProxy     bool
+	trustedProxies []*net.IPNet
+}
 
-CREATE TABLE IF NOT EXISTS waitlist_honeypot (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    email TEXT NOT NULL,
-    trap_value TEXT NOT NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-);`
+// serverConfig bundles the knobs runAPIServer needs beyond where the database lives.
+type serverConfig struct {
+	mailer         Mailer
+	baseURL        string
+	rateLimit      float64
+	rateBurst      int
+	trustProxy     bool
+	trustedProxies []*net.IPNet
+}
 
 func main() {
 	log.SetFlags(0)
@@ -53,21 +62,55 @@ func main() {
 	}
 
 	switch os.Args[1] {
+	case "admin":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, usage(filepath.Base(os.Args[0])))
+			os.Exit(1)
+		}
+		if err := runAdminCmd(os.Args[2], os.Args[3:]); err != nil {
+			log.Fatalf("admin command failed: %v", err)
+		}
 	case "serve":
 		serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
-		dbPath := serveCmd.String("f", "", "path to SQLite database file (defaults to waitlist.db or $DATABASE_PATH)")
+		dbTarget := serveCmd.String("f", "", "path to SQLite database file, or a postgres:// URL (defaults to waitlist.db, $DATABASE_URL, or $DATABASE_PATH)")
+		driver := serveCmd.String("driver", "", "storage driver: sqlite or postgres (defaults to inferring from -f/$DATABASE_URL)")
+		smtpAddr := serveCmd.String("smtp-addr", "", "SMTP server address (host:port) for confirmation emails, or $SMTP_ADDR")
+		smtpUser := serveCmd.String("smtp-user", "", "SMTP username, or $SMTP_USER")
+		smtpPass := serveCmd.String("smtp-pass", "", "SMTP password, or $SMTP_PASS")
+		smtpFrom := serveCmd.String("smtp-from", "", "From address for confirmation emails, or $SMTP_FROM")
+		baseURL := serveCmd.String("base-url", "", "public base URL used in confirmation/unsubscribe links, or $BASE_URL")
+		rate := serveCmd.Float64("rate", defaultRateLimit, "requests per second allowed per client IP on the waitlist endpoint (0 disables rate limiting)")
+		burst := serveCmd.Int("burst", defaultRateBurst, "burst capacity for the per-IP rate limiter")
+		trustProxy := serveCmd.Bool("trust-proxy", false, "honor X-Forwarded-For/Forwarded headers when the immediate peer is in -trusted-proxies")
+		trustedProxies := serveCmd.String("trusted-proxies", "", "comma-separated CIDR allowlist of proxies trusted to set X-Forwarded-For/Forwarded")
 		serveCmd.Parse(os.Args[2:])
 
-		if err := runAPIServer(*dbPath); err != nil {
+		proxyCIDRs, err := parseCIDRList(*trustedProxies)
+		if err != nil {
+			log.Fatalf("invalid -trusted-proxies: %v", err)
+		}
+
+		cfg := serverConfig{
+			mailer:         mailerFromFlags(*smtpAddr, *smtpUser, *smtpPass, *smtpFrom),
+			baseURL:        baseURLFromFlags(*baseURL),
+			rateLimit:      *rate,
+			rateBurst:      *burst,
+			trustProxy:     *trustProxy,
+			trustedProxies: proxyCIDRs,
+		}
+		if err := runAPIServer(*dbTarget, *driver, cfg); err != nil {
 			log.Fatalf("server error: %v", err)
 		}
 	case "list":
 		listCmd := flag.NewFlagSet("list", flag.ExitOnError)
-		dbPath := listCmd.String("f", "", "path to SQLite database file (defaults to waitlist.db or $DATABASE_PATH)")
+		dbTarget := listCmd.String("f", "", "path to SQLite database file, or a postgres:// URL (defaults to waitlist.db, $DATABASE_URL, or $DATABASE_PATH)")
+		driver := listCmd.String("driver", "", "storage driver: sqlite or postgres (defaults to inferring from -f/$DATABASE_URL)")
 		honeypotOnly := listCmd.Bool("honeypot", false, "list only honeypot trap submissions")
+		pendingOnly := listCmd.Bool("pending", false, "list only unconfirmed (pending) signups")
+		ipFilter := listCmd.String("ip", "", "list only entries whose client IP falls within this CIDR")
 		listCmd.Parse(os.Args[2:])
 
-		if err := listWaitlistEntries(*dbPath, os.Stdout, *honeypotOnly); err != nil {
+		if err := listWaitlistEntries(*dbTarget, *driver, os.Stdout, *honeypotOnly, *pendingOnly, *ipFilter); err != nil {
 			log.Fatalf("list failed: %v", err)
 		}
 	case "demo":
@@ -81,7 +124,13 @@ func main() {
 		}
 		log.Printf("demo database created at %s", dbPath)
 
-		if err := runAPIServer(dbPath); err != nil {
+		cfg := serverConfig{
+			mailer:    NullMailer{},
+			baseURL:   baseURLFromFlags(""),
+			rateLimit: defaultRateLimit,
+			rateBurst: defaultRateBurst,
+		}
+		if err := runAPIServer(dbPath, "sqlite", cfg); err != nil {
 			log.Fatalf("demo server error: %v", err)
 		}
 	case "-h", "--help":
@@ -94,32 +143,44 @@ func main() {
 
 func usage(cmd string) string {
 	return fmt.Sprintf(`Usage:
-  %s serve [-f path]
-  %s list [-f path] [--honeypot]
+  %s serve [-f path|url] [-driver sqlite|postgres] [-smtp-addr host:port] [-smtp-user u] [-smtp-pass p] [-smtp-from addr] [-base-url url] [-rate n] [-burst n] [-trust-proxy] [-trusted-proxies cidr,...]
+  %s list [-f path|url] [-driver sqlite|postgres] [--honeypot] [--pending] [--ip cidr]
   %s demo [-dir path]
+  %s admin add-user [-f path|url] [-driver sqlite|postgres] <email>
 
 Commands:
   serve   Start the waitlist HTTP API server.
-  list    Print waitlist entries (use --honeypot for trap submissions).
-  demo    Launch the demo server with a fresh SQLite database.`, cmd, cmd, cmd)
+  list    Print waitlist entries (use --honeypot for trap submissions, --pending for unconfirmed signups, --ip to filter by client IP CIDR).
+  demo    Launch the demo server with a fresh SQLite database.
+  admin   Manage admin users for the HTTP management API.`, cmd, cmd, cmd, cmd)
 }
 
-func runAPIServer(dbPathOverride string) error {
-	dbPath := resolveDatabasePath(dbPathOverride)
+func runAPIServer(dbTargetOverride, driver string, cfg serverConfig) error {
+	target := resolveDatabaseTarget(dbTargetOverride)
 
-	db, err := setupDatabase(dbPath)
+	store, err := openStore(target, driver)
 	if err != nil {
 		return fmt.Errorf("database setup failed: %w", err)
 	}
-	defer db.Close()
+	defer store.Close()
 
-	srv := &server{db: db}
+	srv := &server{
+		store:          store,
+		mailer:         cfg.mailer,
+		baseURL:        cfg.baseURL,
+		rateLimiter:    newRateLimiter(cfg.rateLimit, cfg.rateBurst),
+		trustProxy:     cfg.trustProxy,
+		trustedProxies: cfg.trustedProxies,
+	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/v1/waitlist", srv.waitlistHandler)
+	mux.HandleFunc("/api/v1/waitlist", srv.rateLimitMiddleware(srv.waitlistHandler))
+	mux.HandleFunc("/api/v1/waitlist/confirm", srv.confirmHandler)
+	mux.HandleFunc("/api/v1/waitlist/unsubscribe", srv.unsubscribeHandler)
+	srv.registerAdminRoutes(mux)
 
 	addr := serverAddr()
-	log.Printf("waitlist API listening on %s (database %s)", addr, dbPath)
+	log.Printf("waitlist API listening on %s (database %s)", addr, target)
 
 	if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("listen and serve: %w", err)
@@ -128,83 +189,79 @@ func runAPIServer(dbPathOverride string) error {
 	return nil
 }
 
-func listWaitlistEntries(dbPathOverride string, out io.Writer, honeypotOnly bool) error {
-	dbPath := resolveDatabasePath(dbPathOverride)
+func listWaitlistEntries(dbTargetOverride, driver string, out io.Writer, honeypotOnly, pendingOnly bool, ipFilter string) error {
+	target := resolveDatabaseTarget(dbTargetOverride)
 
-	if dbPath != ":memory:" && !strings.HasPrefix(dbPath, "file:") {
-		if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("database file %q not found", dbPath)
-		} else if err != nil {
-			return fmt.Errorf("stat database: %w", err)
+	var ipNet *net.IPNet
+	if ipFilter != "" {
+		_, parsed, err := net.ParseCIDR(ipFilter)
+		if err != nil {
+			return fmt.Errorf("invalid --ip CIDR: %w", err)
 		}
+		ipNet = parsed
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return fmt.Errorf("open database: %w", err)
+	if driver == "" {
+		driver = inferDriver(target)
 	}
-	defer db.Close()
-
-	if err := initializeDatabase(db); err != nil {
-		return fmt.Errorf("initialize database: %w", err)
+	if driver == "sqlite" {
+		path := strings.TrimPrefix(target, "sqlite://")
+		if path != ":memory:" && !strings.HasPrefix(path, "file:") {
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("database file %q not found", path)
+			} else if err != nil {
+				return fmt.Errorf("stat database: %w", err)
+			}
+		}
 	}
 
-	var rows *sql.Rows
-	queryLabel := "waitlist"
-	if honeypotOnly {
-		rows, err = db.Query(`SELECT id, email, trap_value, created_at FROM waitlist_honeypot ORDER BY created_at ASC, id ASC`)
-		queryLabel = "honeypot"
-	} else {
-		rows, err = db.Query(`SELECT id, email, created_at FROM waitlist ORDER BY created_at ASC, id ASC`)
-	}
+	store, err := openStore(target, driver)
 	if err != nil {
-		return fmt.Errorf("query %s: %w", queryLabel, err)
+		return fmt.Errorf("open database: %w", err)
 	}
-	defer rows.Close()
+	defer store.Close()
 
 	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
 	if honeypotOnly {
-		fmt.Fprintln(tw, "ID\tEmail\tTrap Value\tCreated At")
-	} else {
-		fmt.Fprintln(tw, "ID\tEmail\tCreated At")
-	}
-
-	count := 0
-	for rows.Next() {
-		if honeypotOnly {
-			var (
-				id        int64
-				email     string
-				trapValue string
-				created   string
-			)
-			if err := rows.Scan(&id, &email, &trapValue, &created); err != nil {
-				return fmt.Errorf("scan row: %w", err)
-			}
-			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", id, email, trapValue, created)
-		} else {
-			var (
-				id      int64
-				email   string
-				created string
-			)
-			if err := rows.Scan(&id, &email, &created); err != nil {
-				return fmt.Errorf("scan row: %w", err)
+		entries, err := store.ListHoneypot(context.Background())
+		if err != nil {
+			return fmt.Errorf("query honeypot: %w", err)
+		}
+
+		if ipNet != nil {
+			filtered := entries[:0]
+			for _, e := range entries {
+				if ip := net.ParseIP(e.ClientIP); ip != nil && ipNet.Contains(ip) {
+					filtered = append(filtered, e)
+				}
 			}
-			fmt.Fprintf(tw, "%d\t%s\t%s\n", id, email, created)
+			entries = filtered
 		}
-		count++
-	}
 
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("iterate rows: %w", err)
-	}
+		fmt.Fprintln(tw, "ID\tEmail\tTrap Value\tClient IP\tCreated At")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", e.ID, e.Email, e.TrapValue, e.ClientIP, e.CreatedAt)
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(tw, "(no honeypot entries)\t\t\t\t")
+		}
+	} else {
+		entries, err := store.ListWaitlist(context.Background(), pendingOnly)
+		if err != nil {
+			return fmt.Errorf("query waitlist: %w", err)
+		}
+
+		if ipNet != nil {
+			entries = filterByIP(entries, ipNet)
+		}
 
-	if count == 0 {
-		if honeypotOnly {
-			fmt.Fprintln(tw, "(no honeypot entries)\t\t\t")
-		} else {
-			fmt.Fprintln(tw, "(no entries)\t\t")
+		fmt.Fprintln(tw, "ID\tEmail\tStatus\tClient IP\tCreated At")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", e.ID, e.Email, e.Status, e.ClientIP, e.CreatedAt)
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(tw, "(no entries)\t\t\t\t")
 		}
 	}
 
@@ -215,6 +272,16 @@ func listWaitlistEntries(dbPathOverride string, out io.Writer, honeypotOnly bool
 	return nil
 }
 
+func filterByIP(entries []WaitlistRecord, ipNet *net.IPNet) []WaitlistRecord {
+	filtered := entries[:0]
+	for _, e := range entries {
+		if ip := net.ParseIP(e.ClientIP); ip != nil && ipNet.Contains(ip) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
 func (s *server) waitlistHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.Header().Set("Allow", http.MethodPost)
@@ -222,12 +289,9 @@ func (s *server) waitlistHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	contentType := r.Header.Get("Content-Type")
-	if idx := strings.Index(contentType, ";"); idx != -1 {
-		contentType = strings.TrimSpace(contentType[:idx])
-	}
-
-	isJSON := contentType == "application/json"
+	isJSON := requestIsJSON(r)
+	clientIP := s.resolveClientIP(r)
+	userAgent := r.UserAgent()
 
 	email := ""
 	trapValue := ""
@@ -253,7 +317,7 @@ func (s *server) waitlistHandler(w http.ResponseWriter, r *http.Request) {
 	trapValue = strings.TrimSpace(trapValue)
 
 	if trapValue != "" {
-		if err := s.insertHoneypot(r.Context(), email, trapValue); err != nil {
+		if err := s.store.InsertHoneypot(r.Context(), email, trapValue, clientIP, userAgent); err != nil {
 			log.Printf("failed to insert honeypot entry: %v", err)
 			writeMessage(w, http.StatusInternalServerError, "internal server error", !isJSON)
 			return
@@ -273,63 +337,112 @@ func (s *server) waitlistHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.insertWaitlist(r.Context(), email); err != nil {
-		if isUniqueConstraint(err) {
-			writeMessage(w, http.StatusConflict, "email already registered", !isJSON)
-			return
-		}
+	conflict, err := s.insertWaitlist(r.Context(), email, clientIP, userAgent)
+	if err != nil {
 		log.Printf("failed to insert email: %v", err)
 		writeMessage(w, http.StatusInternalServerError, "internal server error", !isJSON)
 		return
 	}
+	if conflict {
+		writeMessage(w, http.StatusConflict, "email already registered", !isJSON)
+		return
+	}
 
 	writeMessage(w, http.StatusCreated, "email accepted for waitlist", !isJSON)
 }
 
-func (s *server) insertWaitlist(ctx context.Context, email string) error {
-	_, err := s.db.ExecContext(ctx, `INSERT INTO waitlist(email) VALUES (?)`, email)
-	return err
-}
+// insertWaitlist records a pending signup and dispatches its confirmation
+// email. Generating the confirmation token, persisting it with its expiry,
+// and sending the email all happen as part of handling a single signup, and
+// resubmitting the same pending email reuses its still-valid token instead of
+// minting a new one, so retried requests are safe to repeat. It reports
+// conflict=true when email is already a confirmed entry.
+func (s *server) insertWaitlist(ctx context.Context, email, clientIP, userAgent string) (conflict bool, err error) {
+	token, err := generateToken()
+	if err != nil {
+		return false, fmt.Errorf("generate confirmation token: %w", err)
+	}
+	expiresAt := time.Now().UTC().Add(confirmationTokenTTL).Format(time.RFC3339)
 
-func (s *server) insertHoneypot(ctx context.Context, email, trapValue string) error {
-	_, err := s.db.ExecContext(ctx, `INSERT INTO waitlist_honeypot(email, trap_value) VALUES (?, ?)`, email, trapValue)
-	return err
-}
+	activeToken, alreadyConfirmed, err := s.store.UpsertPendingSignup(ctx, email, token, expiresAt, clientIP, userAgent)
+	if err != nil {
+		return false, err
+	}
+	if alreadyConfirmed {
+		return true, nil
+	}
+
+	confirmURL := buildConfirmURL(s.baseURL, "/api/v1/waitlist/confirm", activeToken)
+	unsubscribeURL := buildConfirmURL(s.baseURL, "/api/v1/waitlist/unsubscribe", activeToken)
+	if err := s.mailer.SendConfirmation(ctx, email, confirmURL, unsubscribeURL); err != nil {
+		return false, fmt.Errorf("send confirmation email: %w", err)
+	}
 
-func isUniqueConstraint(err error) bool {
-	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+	return false, nil
 }
 
-func setupDatabase(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite", path)
-	if err != nil {
-		return nil, err
+func (s *server) confirmHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	if err := initializeDatabase(db); err != nil {
-		db.Close()
-		return nil, err
+	token := strings.TrimSpace(r.URL.Query().Get("token"))
+	if token == "" {
+		writeMessage(w, http.StatusBadRequest, "confirmation token is required", true)
+		return
 	}
 
-	return db, nil
+	confirmed, err := s.store.ConfirmToken(r.Context(), token)
+	if err != nil {
+		log.Printf("failed to confirm waitlist entry: %v", err)
+		writeMessage(w, http.StatusInternalServerError, "internal server error", true)
+		return
+	}
+	if !confirmed {
+		writeMessage(w, http.StatusBadRequest, "confirmation link is invalid or expired", true)
+		return
+	}
+
+	unsubscribeURL := buildConfirmURL(s.baseURL, "/api/v1/waitlist/unsubscribe", token)
+	writeMessage(w, http.StatusOK, fmt.Sprintf("email confirmed. Changed your mind? Unsubscribe here: %s", unsubscribeURL), true)
 }
 
-func initializeDatabase(db *sql.DB) error {
-	if err := db.Ping(); err != nil {
-		return err
+func (s *server) unsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	if _, err := db.Exec(schema); err != nil {
-		return err
+	token := strings.TrimSpace(r.URL.Query().Get("token"))
+	if token == "" {
+		writeMessage(w, http.StatusBadRequest, "unsubscribe token is required", true)
+		return
 	}
 
-	return nil
+	removed, err := s.store.DeleteWaitlistByToken(r.Context(), token)
+	if err != nil {
+		log.Printf("failed to unsubscribe waitlist entry: %v", err)
+		writeMessage(w, http.StatusInternalServerError, "internal server error", true)
+		return
+	}
+	if !removed {
+		writeMessage(w, http.StatusNotFound, "unsubscribe token is invalid", true)
+		return
+	}
+
+	writeMessage(w, http.StatusOK, "you have been removed from the waitlist", true)
 }
 
-func resolveDatabasePath(override string) string {
+func resolveDatabaseTarget(override string) string {
 	if override != "" {
 		return override
 	}
+	if url, ok := os.LookupEnv("DATABASE_URL"); ok && url != "" {
+		return url
+	}
 	return databasePath()
 }
 
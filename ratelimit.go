@@ -0,0 +1,195 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRateLimit and defaultRateBurst are the per-IP token-bucket settings
+// applied to the waitlist endpoint when -rate/-burst aren't overridden.
+const (
+	defaultRateLimit = 1.0
+	defaultRateBurst = 5
+)
+
+// staleBucketTTL bounds how long an idle IP's bucket is kept around; buckets
+// older than this are swept out the next time rateLimiter grows past
+// staleBucketSweepThreshold entries, so long-running servers don't leak
+// memory for one-off clients.
+const staleBucketTTL = 10 * time.Minute
+const staleBucketSweepThreshold = 10000
+
+// rateLimiter is a per-key token-bucket limiter, keyed by client IP.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// newRateLimiter builds a limiter allowing rate requests/sec per key, with a
+// burst capacity of burst. A non-positive rate disables limiting entirely.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow reports whether a request from key is within the rate limit,
+// consuming a token if so.
+func (rl *rateLimiter) Allow(key string) bool {
+	if rl.rate <= 0 {
+		return true
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if len(rl.buckets) > staleBucketSweepThreshold {
+		rl.sweepLocked(now)
+	}
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: rl.burst, lastSeen: now}
+		rl.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens += elapsed * rl.rate
+	if b.tokens > rl.burst {
+		b.tokens = rl.burst
+	}
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (rl *rateLimiter) sweepLocked(now time.Time) {
+	for key, b := range rl.buckets {
+		if now.Sub(b.lastSeen) > staleBucketTTL {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// rateLimitMiddleware rejects requests over the per-IP rate limit with a 429,
+// honoring the same JSON/HTML content negotiation as writeMessage elsewhere.
+func (s *server) rateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.rateLimiter != nil && !s.rateLimiter.Allow(s.resolveClientIP(r)) {
+			writeMessage(w, http.StatusTooManyRequests, "rate limit exceeded, please try again later", !requestIsJSON(r))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// resolveClientIP returns the client's IP, trusting X-Forwarded-For/Forwarded
+// only when trust-proxy mode is enabled and the immediate peer is in the
+// configured CIDR allowlist.
+func (s *server) resolveClientIP(r *http.Request) string {
+	return resolveClientIP(r, s.trustProxy, s.trustedProxies)
+}
+
+func resolveClientIP(r *http.Request, trustProxy bool, trustedProxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !trustProxy {
+		return host
+	}
+
+	peer := net.ParseIP(host)
+	if peer == nil || !ipInCIDRs(peer, trustedProxies) {
+		return host
+	}
+
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		first := strings.TrimSpace(strings.Split(fwd, ",")[0])
+		if first != "" {
+			return first
+		}
+	}
+
+	if fwd := r.Header.Get("Forwarded"); fwd != "" {
+		if ip := parseForwardedFor(fwd); ip != "" {
+			return ip
+		}
+	}
+
+	return host
+}
+
+// parseForwardedFor extracts the "for=" address from an RFC 7239 Forwarded
+// header's first element, e.g. `for=203.0.113.1;proto=https`.
+func parseForwardedFor(header string) string {
+	first := strings.Split(header, ",")[0]
+	for _, part := range strings.Split(first, ";") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(strings.ToLower(part), "for=") {
+			continue
+		}
+		value := strings.TrimSpace(part[len("for="):])
+		value = strings.Trim(value, `"`)
+		value = strings.TrimPrefix(value, "[")
+		if idx := strings.LastIndex(value, "]"); idx != -1 {
+			value = value[:idx]
+		} else if idx := strings.LastIndex(value, ":"); idx != -1 && strings.Count(value, ":") == 1 {
+			value = value[:idx]
+		}
+		return value
+	}
+	return ""
+}
+
+func ipInCIDRs(ip net.IP, cidrs []*net.IPNet) bool {
+	for _, c := range cidrs {
+		if c.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDRList parses a comma-separated list of CIDR ranges, as used by -trusted-proxies.
+func parseCIDRList(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func requestIsJSON(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+	return contentType == "application/json"
+}
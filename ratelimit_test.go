@@ -0,0 +1,139 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllowBurstAndRefill(t *testing.T) {
+	rl := newRateLimiter(1, 2)
+
+	if !rl.Allow("1.2.3.4") {
+		t.Fatalf("first request within burst was rejected")
+	}
+	if !rl.Allow("1.2.3.4") {
+		t.Fatalf("second request within burst was rejected")
+	}
+	if rl.Allow("1.2.3.4") {
+		t.Fatalf("third request exceeded burst capacity but was allowed")
+	}
+
+	b := rl.buckets["1.2.3.4"]
+	b.lastSeen = b.lastSeen.Add(-1500 * time.Millisecond)
+	if !rl.Allow("1.2.3.4") {
+		t.Fatalf("request after refill window elapsed was still rejected")
+	}
+}
+
+func TestRateLimiterKeysAreIndependent(t *testing.T) {
+	rl := newRateLimiter(1, 1)
+
+	if !rl.Allow("1.1.1.1") {
+		t.Fatalf("first request for key 1 was rejected")
+	}
+	if !rl.Allow("2.2.2.2") {
+		t.Fatalf("first request for key 2 was rejected by key 1's exhausted bucket")
+	}
+	if rl.Allow("1.1.1.1") {
+		t.Fatalf("second immediate request for key 1 should have been rejected")
+	}
+}
+
+func TestRateLimiterDisabledWhenRateNonPositive(t *testing.T) {
+	rl := newRateLimiter(0, 1)
+	for i := 0; i < 5; i++ {
+		if !rl.Allow("1.2.3.4") {
+			t.Fatalf("rate limiter with rate<=0 rejected a request, want unlimited")
+		}
+	}
+}
+
+func mustCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatalf("ParseCIDR(%q): %v", s, err)
+	}
+	return n
+}
+
+func TestResolveClientIPUntrustedDefaultsToPeer(t *testing.T) {
+	r := &http.Request{RemoteAddr: "203.0.113.5:443", Header: http.Header{"X-Forwarded-For": {"9.9.9.9"}}}
+	got := resolveClientIP(r, false, nil)
+	if got != "203.0.113.5" {
+		t.Fatalf("resolveClientIP with trustProxy=false = %q, want the peer address", got)
+	}
+}
+
+func TestResolveClientIPTrustedProxyUsesForwardedFor(t *testing.T) {
+	trusted := []*net.IPNet{mustCIDR(t, "10.0.0.0/8")}
+	r := &http.Request{RemoteAddr: "10.1.2.3:443", Header: http.Header{"X-Forwarded-For": {"203.0.113.9, 10.1.2.3"}}}
+	got := resolveClientIP(r, true, trusted)
+	if got != "203.0.113.9" {
+		t.Fatalf("resolveClientIP = %q, want leftmost X-Forwarded-For entry", got)
+	}
+}
+
+func TestResolveClientIPUntrustedPeerIgnoresForwardedFor(t *testing.T) {
+	trusted := []*net.IPNet{mustCIDR(t, "10.0.0.0/8")}
+	r := &http.Request{RemoteAddr: "203.0.113.5:443", Header: http.Header{"X-Forwarded-For": {"9.9.9.9"}}}
+	got := resolveClientIP(r, true, trusted)
+	if got != "203.0.113.5" {
+		t.Fatalf("resolveClientIP trusted an untrusted peer's X-Forwarded-For header: got %q", got)
+	}
+}
+
+func TestParseForwardedForIPv4(t *testing.T) {
+	got := parseForwardedFor(`for=203.0.113.1;proto=https, for=10.0.0.1`)
+	if got != "203.0.113.1" {
+		t.Fatalf("parseForwardedFor = %q, want 203.0.113.1", got)
+	}
+}
+
+func TestParseForwardedForIPv4WithPort(t *testing.T) {
+	got := parseForwardedFor(`for=203.0.113.1:8080`)
+	if got != "203.0.113.1" {
+		t.Fatalf("parseForwardedFor = %q, want 203.0.113.1 with the port stripped", got)
+	}
+}
+
+func TestParseForwardedForIPv6Bracketed(t *testing.T) {
+	got := parseForwardedFor(`for="[2001:db8::1]:8080"`)
+	if got != "2001:db8::1" {
+		t.Fatalf("parseForwardedFor = %q, want 2001:db8::1 with brackets and port stripped", got)
+	}
+}
+
+func TestParseForwardedForIPv6NoPort(t *testing.T) {
+	got := parseForwardedFor(`for="[2001:db8::1]"`)
+	if got != "2001:db8::1" {
+		t.Fatalf("parseForwardedFor = %q, want 2001:db8::1", got)
+	}
+}
+
+func TestIPInCIDRs(t *testing.T) {
+	cidrs := []*net.IPNet{mustCIDR(t, "10.0.0.0/8"), mustCIDR(t, "192.168.0.0/16")}
+
+	if !ipInCIDRs(net.ParseIP("10.1.2.3"), cidrs) {
+		t.Fatalf("expected 10.1.2.3 to match 10.0.0.0/8")
+	}
+	if ipInCIDRs(net.ParseIP("203.0.113.1"), cidrs) {
+		t.Fatalf("expected 203.0.113.1 to match no configured CIDR")
+	}
+}
+
+func TestParseCIDRList(t *testing.T) {
+	nets, err := parseCIDRList("10.0.0.0/8, 192.168.0.0/16,")
+	if err != nil {
+		t.Fatalf("parseCIDRList: %v", err)
+	}
+	if len(nets) != 2 {
+		t.Fatalf("parseCIDRList returned %d entries, want 2", len(nets))
+	}
+
+	if _, err := parseCIDRList("not-a-cidr"); err == nil {
+		t.Fatalf("parseCIDRList accepted an invalid CIDR")
+	}
+}
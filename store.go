@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUserAlreadyExists is returned by AddAdminUser when the email is already registered.
+var ErrUserAlreadyExists = errors.New("admin user already exists")
+
+// WaitlistRecord is a single waitlist row, independent of storage backend.
+type WaitlistRecord struct {
+	ID        int64
+	Email     string
+	Status    string
+	ClientIP  string
+	UserAgent string
+	CreatedAt string
+}
+
+// HoneypotRecord is a single waitlist_honeypot row, independent of storage backend.
+type HoneypotRecord struct {
+	ID        int64
+	Email     string
+	TrapValue string
+	ClientIP  string
+	UserAgent string
+	CreatedAt string
+}
+
+// Store abstracts waitlist persistence so the SQLite and Postgres backends can
+// be swapped via -driver/$DATABASE_URL without the HTTP and CLI layers caring
+// which one is in use.
+type Store interface {
+	// UpsertPendingSignup records a pending signup under token/expiresAt. If
+	// email is already pending, it reuses that row's existing token when it
+	// hasn't expired yet, or refreshes it otherwise, so retrying the same
+	// signup is idempotent. alreadyConfirmed reports whether email is already
+	// a confirmed entry, in which case activeToken is meaningless.
+	UpsertPendingSignup(ctx context.Context, email, token, expiresAt, clientIP, userAgent string) (activeToken string, alreadyConfirmed bool, err error)
+	ConfirmToken(ctx context.Context, token string) (bool, error)
+	DeleteWaitlistByToken(ctx context.Context, token string) (bool, error)
+	DeleteWaitlistByID(ctx context.Context, id int64) (bool, error)
+	ListWaitlist(ctx context.Context, pendingOnly bool) ([]WaitlistRecord, error)
+
+	InsertHoneypot(ctx context.Context, email, trapValue, clientIP, userAgent string) error
+	ListHoneypot(ctx context.Context) ([]HoneypotRecord, error)
+
+	AddAdminUser(ctx context.Context, email, tokenHash string) error
+	AdminTokenHashes(ctx context.Context) ([]string, error)
+
+	// IsUniqueViolation reports whether err is a unique-constraint violation
+	// as reported by this backend's driver.
+	IsUniqueViolation(err error) bool
+
+	Close() error
+}
+
+// openStore builds the Store selected by driver, falling back to inferring it
+// from target's URL scheme (sqlite://, postgres://, postgresql://) when driver
+// is empty. A bare filesystem path with no scheme is treated as SQLite, the
+// long-standing default.
+func openStore(target, driver string) (Store, error) {
+	if driver == "" {
+		driver = inferDriver(target)
+	}
+
+	switch driver {
+	case "sqlite":
+		return newSQLiteStore(strings.TrimPrefix(target, "sqlite://"))
+	case "postgres":
+		return newPostgresStore(target)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
+}
+
+func inferDriver(target string) string {
+	switch {
+	case strings.HasPrefix(target, "postgres://"), strings.HasPrefix(target, "postgresql://"):
+		return "postgres"
+	default:
+		return "sqlite"
+	}
+}
+
+// InMemory opens an ephemeral SQLite-backed Store, useful for tests that need
+// a working Store without touching disk.
+func InMemory() (Store, error) {
+	return newSQLiteStore(":memory:")
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS waitlist (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    confirmation_token TEXT,
+    confirmation_expires_at TIMESTAMPTZ,
+    client_ip TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS waitlist_honeypot (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL,
+    trap_value TEXT NOT NULL,
+    client_ip TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    token_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`
+
+// postgresStore is the Store implementation backed by lib/pq.
+type postgresStore struct {
+	db *sql.DB
+}
+
+func newPostgresStore(dsn string) (Store, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if _, err := db.Exec(postgresSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &postgresStore{db: db}, nil
+}
+
+func (s *postgresStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *postgresStore) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation"
+}
+
+func (s *postgresStore) UpsertPendingSignup(ctx context.Context, email, token, expiresAt, clientIP, userAgent string) (string, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO waitlist(email, status, confirmation_token, confirmation_expires_at, client_ip, user_agent) VALUES ($1, $2, $3, $4, $5, $6)`,
+		email, waitlistStatusPending, token, expiresAt, clientIP, userAgent)
+	if err != nil {
+		if !s.IsUniqueViolation(err) {
+			return "", false, err
+		}
+
+		var (
+			status         string
+			existingToken  string
+			existingExpiry string
+		)
+		row := tx.QueryRowContext(ctx, `SELECT status, confirmation_token, confirmation_expires_at FROM waitlist WHERE email = $1`, email)
+		if scanErr := row.Scan(&status, &existingToken, &existingExpiry); scanErr != nil {
+			return "", false, scanErr
+		}
+
+		if status == waitlistStatusConfirmed {
+			return "", true, nil
+		}
+
+		expiry, parseErr := time.Parse(time.RFC3339, existingExpiry)
+		if parseErr != nil || time.Now().UTC().After(expiry) {
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE waitlist SET confirmation_token = $1, confirmation_expires_at = $2, client_ip = $3, user_agent = $4 WHERE email = $5`,
+				token, expiresAt, clientIP, userAgent, email); err != nil {
+				return "", false, err
+			}
+		} else {
+			token = existingToken
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", false, err
+	}
+
+	return token, false, nil
+}
+
+func (s *postgresStore) ConfirmToken(ctx context.Context, token string) (bool, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE waitlist SET status = $1 WHERE confirmation_token = $2 AND status = $3 AND confirmation_expires_at > $4`,
+		waitlistStatusConfirmed, token, waitlistStatusPending, now)
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected(res)
+}
+
+func (s *postgresStore) DeleteWaitlistByToken(ctx context.Context, token string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM waitlist WHERE confirmation_token = $1`, token)
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected(res)
+}
+
+func (s *postgresStore) DeleteWaitlistByID(ctx context.Context, id int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM waitlist WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected(res)
+}
+
+func (s *postgresStore) ListWaitlist(ctx context.Context, pendingOnly bool) ([]WaitlistRecord, error) {
+	query := `SELECT id, email, status, client_ip, user_agent, created_at FROM waitlist ORDER BY created_at ASC, id ASC`
+	if pendingOnly {
+		query = `SELECT id, email, status, client_ip, user_agent, created_at FROM waitlist WHERE status = 'pending' ORDER BY created_at ASC, id ASC`
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []WaitlistRecord
+	for rows.Next() {
+		var (
+			e         WaitlistRecord
+			clientIP  sql.NullString
+			userAgent sql.NullString
+			createdAt time.Time
+		)
+		if err := rows.Scan(&e.ID, &e.Email, &e.Status, &clientIP, &userAgent, &createdAt); err != nil {
+			return nil, err
+		}
+		e.ClientIP, e.UserAgent = clientIP.String, userAgent.String
+		e.CreatedAt = createdAt.Format(time.RFC3339)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *postgresStore) InsertHoneypot(ctx context.Context, email, trapValue, clientIP, userAgent string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO waitlist_honeypot(email, trap_value, client_ip, user_agent) VALUES ($1, $2, $3, $4)`,
+		email, trapValue, clientIP, userAgent)
+	return err
+}
+
+func (s *postgresStore) ListHoneypot(ctx context.Context) ([]HoneypotRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, email, trap_value, client_ip, user_agent, created_at FROM waitlist_honeypot ORDER BY created_at ASC, id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HoneypotRecord
+	for rows.Next() {
+		var (
+			e         HoneypotRecord
+			clientIP  sql.NullString
+			userAgent sql.NullString
+			createdAt time.Time
+		)
+		if err := rows.Scan(&e.ID, &e.Email, &e.TrapValue, &clientIP, &userAgent, &createdAt); err != nil {
+			return nil, err
+		}
+		e.ClientIP, e.UserAgent = clientIP.String, userAgent.String
+		e.CreatedAt = createdAt.Format(time.RFC3339)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *postgresStore) AddAdminUser(ctx context.Context, email, tokenHash string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO users(email, token_hash) VALUES ($1, $2)`, email, tokenHash)
+	if err != nil && s.IsUniqueViolation(err) {
+		return ErrUserAlreadyExists
+	}
+	return err
+}
+
+func (s *postgresStore) AdminTokenHashes(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT token_hash FROM users`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
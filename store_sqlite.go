@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS waitlist (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL UNIQUE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    confirmation_token TEXT,
+    confirmation_expires_at TEXT,
+    client_ip TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS waitlist_honeypot (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    trap_value TEXT NOT NULL,
+    client_ip TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL UNIQUE,
+    token_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+
+// sqliteColumnMigrations adds columns introduced after the initial waitlist
+// schema to databases created before they existed. modernc.org/sqlite reports
+// duplicate columns as an error, so failures here are ignored rather than
+// treated as fatal.
+var sqliteColumnMigrations = []string{
+	`ALTER TABLE waitlist ADD COLUMN status TEXT NOT NULL DEFAULT 'pending'`,
+	`ALTER TABLE waitlist ADD COLUMN confirmation_token TEXT`,
+	`ALTER TABLE waitlist ADD COLUMN confirmation_expires_at TEXT`,
+	`ALTER TABLE waitlist ADD COLUMN client_ip TEXT`,
+	`ALTER TABLE waitlist ADD COLUMN user_agent TEXT`,
+	`ALTER TABLE waitlist_honeypot ADD COLUMN client_ip TEXT`,
+	`ALTER TABLE waitlist_honeypot ADD COLUMN user_agent TEXT`,
+}
+
+// sqliteStore is the Store implementation backed by modernc.org/sqlite.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func newSQLiteStore(path string) (Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	for _, stmt := range sqliteColumnMigrations {
+		db.Exec(stmt) // ignore error: column already exists on databases created with the current schema
+	}
+
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteStore) IsUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (s *sqliteStore) UpsertPendingSignup(ctx context.Context, email, token, expiresAt, clientIP, userAgent string) (string, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO waitlist(email, status, confirmation_token, confirmation_expires_at, client_ip, user_agent) VALUES (?, ?, ?, ?, ?, ?)`,
+		email, waitlistStatusPending, token, expiresAt, clientIP, userAgent)
+	if err != nil {
+		if !s.IsUniqueViolation(err) {
+			return "", false, err
+		}
+
+		var (
+			status         string
+			existingToken  string
+			existingExpiry string
+		)
+		row := tx.QueryRowContext(ctx, `SELECT status, confirmation_token, confirmation_expires_at FROM waitlist WHERE email = ?`, email)
+		if scanErr := row.Scan(&status, &existingToken, &existingExpiry); scanErr != nil {
+			return "", false, scanErr
+		}
+
+		if status == waitlistStatusConfirmed {
+			return "", true, nil
+		}
+
+		expiry, parseErr := time.Parse(time.RFC3339, existingExpiry)
+		if parseErr != nil || time.Now().UTC().After(expiry) {
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE waitlist SET confirmation_token = ?, confirmation_expires_at = ?, client_ip = ?, user_agent = ? WHERE email = ?`,
+				token, expiresAt, clientIP, userAgent, email); err != nil {
+				return "", false, err
+			}
+		} else {
+			token = existingToken
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", false, err
+	}
+
+	return token, false, nil
+}
+
+func (s *sqliteStore) ConfirmToken(ctx context.Context, token string) (bool, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE waitlist SET status = ? WHERE confirmation_token = ? AND status = ? AND confirmation_expires_at > ?`,
+		waitlistStatusConfirmed, token, waitlistStatusPending, now)
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected(res)
+}
+
+func (s *sqliteStore) DeleteWaitlistByToken(ctx context.Context, token string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM waitlist WHERE confirmation_token = ?`, token)
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected(res)
+}
+
+func (s *sqliteStore) DeleteWaitlistByID(ctx context.Context, id int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM waitlist WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected(res)
+}
+
+func (s *sqliteStore) ListWaitlist(ctx context.Context, pendingOnly bool) ([]WaitlistRecord, error) {
+	query := `SELECT id, email, status, client_ip, user_agent, created_at FROM waitlist ORDER BY created_at ASC, id ASC`
+	if pendingOnly {
+		query = `SELECT id, email, status, client_ip, user_agent, created_at FROM waitlist WHERE status = 'pending' ORDER BY created_at ASC, id ASC`
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []WaitlistRecord
+	for rows.Next() {
+		var (
+			e         WaitlistRecord
+			clientIP  sql.NullString
+			userAgent sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &e.Email, &e.Status, &clientIP, &userAgent, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.ClientIP, e.UserAgent = clientIP.String, userAgent.String
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *sqliteStore) InsertHoneypot(ctx context.Context, email, trapValue, clientIP, userAgent string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO waitlist_honeypot(email, trap_value, client_ip, user_agent) VALUES (?, ?, ?, ?)`,
+		email, trapValue, clientIP, userAgent)
+	return err
+}
+
+func (s *sqliteStore) ListHoneypot(ctx context.Context) ([]HoneypotRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, email, trap_value, client_ip, user_agent, created_at FROM waitlist_honeypot ORDER BY created_at ASC, id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HoneypotRecord
+	for rows.Next() {
+		var (
+			e         HoneypotRecord
+			clientIP  sql.NullString
+			userAgent sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &e.Email, &e.TrapValue, &clientIP, &userAgent, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.ClientIP, e.UserAgent = clientIP.String, userAgent.String
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *sqliteStore) AddAdminUser(ctx context.Context, email, tokenHash string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO users(email, token_hash) VALUES (?, ?)`, email, tokenHash)
+	if err != nil && s.IsUniqueViolation(err) {
+		return ErrUserAlreadyExists
+	}
+	return err
+}
+
+func (s *sqliteStore) AdminTokenHashes(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT token_hash FROM users`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
+
+func rowsAffected(res sql.Result) (bool, error) {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
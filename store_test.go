@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+	store, err := InMemory()
+	if err != nil {
+		t.Fatalf("InMemory: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestUpsertPendingSignupIsIdempotent(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	expiresAt := time.Now().UTC().Add(confirmationTokenTTL).Format(time.RFC3339)
+
+	token1, confirmed, err := store.UpsertPendingSignup(ctx, "a@example.com", "token-1", expiresAt, "203.0.113.1", "curl/8.0")
+	if err != nil {
+		t.Fatalf("first upsert: %v", err)
+	}
+	if confirmed {
+		t.Fatalf("first upsert reported alreadyConfirmed for a brand new signup")
+	}
+
+	token2, confirmed, err := store.UpsertPendingSignup(ctx, "a@example.com", "token-2", expiresAt, "203.0.113.2", "curl/8.1")
+	if err != nil {
+		t.Fatalf("second upsert: %v", err)
+	}
+	if confirmed {
+		t.Fatalf("second upsert reported alreadyConfirmed before any confirmation")
+	}
+	if token2 != token1 {
+		t.Fatalf("resubmitting a still-valid pending signup minted a new token: got %q, want %q", token2, token1)
+	}
+}
+
+func TestUpsertPendingSignupAfterConfirm(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	expiresAt := time.Now().UTC().Add(confirmationTokenTTL).Format(time.RFC3339)
+
+	token, _, err := store.UpsertPendingSignup(ctx, "b@example.com", "token-1", expiresAt, "203.0.113.1", "curl/8.0")
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	confirmed, err := store.ConfirmToken(ctx, token)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if !confirmed {
+		t.Fatalf("ConfirmToken did not confirm a valid, unexpired token")
+	}
+
+	if _, confirmed, err := store.UpsertPendingSignup(ctx, "b@example.com", "token-2", expiresAt, "203.0.113.2", "curl/8.1"); err != nil {
+		t.Fatalf("upsert after confirm: %v", err)
+	} else if !confirmed {
+		t.Fatalf("upsert did not report alreadyConfirmed for an already-confirmed email")
+	}
+}
+
+func TestListWaitlistPendingOnly(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	expiresAt := time.Now().UTC().Add(confirmationTokenTTL).Format(time.RFC3339)
+
+	if _, _, err := store.UpsertPendingSignup(ctx, "pending@example.com", "token-1", expiresAt, "203.0.113.1", "ua-1"); err != nil {
+		t.Fatalf("upsert pending: %v", err)
+	}
+	if _, _, err := store.UpsertPendingSignup(ctx, "confirmed@example.com", "token-2", expiresAt, "203.0.113.2", "ua-2"); err != nil {
+		t.Fatalf("upsert confirmed: %v", err)
+	}
+	if _, err := store.ConfirmToken(ctx, "token-2"); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	pending, err := store.ListWaitlist(ctx, true)
+	if err != nil {
+		t.Fatalf("list pending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Email != "pending@example.com" {
+		t.Fatalf("ListWaitlist(pendingOnly=true) = %+v, want only pending@example.com", pending)
+	}
+
+	all, err := store.ListWaitlist(ctx, false)
+	if err != nil {
+		t.Fatalf("list all: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("ListWaitlist(pendingOnly=false) returned %d entries, want 2", len(all))
+	}
+}
+
+func TestInsertAndListHoneypot(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.InsertHoneypot(ctx, "trap@example.com", "website", "203.0.113.9", "ua-trap"); err != nil {
+		t.Fatalf("insert honeypot: %v", err)
+	}
+
+	entries, err := store.ListHoneypot(ctx)
+	if err != nil {
+		t.Fatalf("list honeypot: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("ListHoneypot returned %d entries, want 1", len(entries))
+	}
+	if got := entries[0]; got.Email != "trap@example.com" || got.TrapValue != "website" || got.ClientIP != "203.0.113.9" || got.UserAgent != "ua-trap" {
+		t.Fatalf("ListHoneypot entry = %+v, want matching email/trap_value/client_ip/user_agent", got)
+	}
+}
+
+func TestDeleteWaitlistByID(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	expiresAt := time.Now().UTC().Add(confirmationTokenTTL).Format(time.RFC3339)
+
+	if _, _, err := store.UpsertPendingSignup(ctx, "delete-me@example.com", "token-1", expiresAt, "203.0.113.1", "ua"); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	entries, err := store.ListWaitlist(ctx, false)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ListWaitlist: %v, %+v", err, entries)
+	}
+
+	removed, err := store.DeleteWaitlistByID(ctx, entries[0].ID)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !removed {
+		t.Fatalf("DeleteWaitlistByID reported nothing removed for an existing row")
+	}
+
+	if removed, err := store.DeleteWaitlistByID(ctx, entries[0].ID); err != nil {
+		t.Fatalf("delete again: %v", err)
+	} else if removed {
+		t.Fatalf("DeleteWaitlistByID reported a row removed on a second delete of the same id")
+	}
+}
+
+func TestAdminUserTokenLookup(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.AddAdminUser(ctx, "admin@example.com", "hash-1"); err != nil {
+		t.Fatalf("add admin user: %v", err)
+	}
+
+	if err := store.AddAdminUser(ctx, "admin@example.com", "hash-2"); !errors.Is(err, ErrUserAlreadyExists) {
+		t.Fatalf("AddAdminUser duplicate error = %v, want ErrUserAlreadyExists", err)
+	}
+
+	hashes, err := store.AdminTokenHashes(ctx)
+	if err != nil {
+		t.Fatalf("admin token hashes: %v", err)
+	}
+	if len(hashes) != 1 || hashes[0] != "hash-1" {
+		t.Fatalf("AdminTokenHashes = %v, want [hash-1]", hashes)
+	}
+}